@@ -0,0 +1,116 @@
+package calc
+
+import (
+	"fmt"
+	"math"
+)
+
+// Eval 返回该数字字面量的值
+func (n *NumberNode) Eval(steps *[]string) (float64, error) {
+	return n.Value, nil
+}
+
+// Eval 对一元运算求值
+func (n *UnaryNode) Eval(steps *[]string) (float64, error) {
+	v, err := n.Operand.Eval(steps)
+	if err != nil {
+		return 0, err
+	}
+	result := -v
+	*steps = append(*steps, fmt.Sprintf("-(%s) = %s", formatNum(v), formatNum(result)))
+	return result, nil
+}
+
+// Eval 对二元运算求值，显式处理除零和溢出
+func (n *BinaryNode) Eval(steps *[]string) (float64, error) {
+	left, err := n.Left.Eval(steps)
+	if err != nil {
+		return 0, err
+	}
+	right, err := n.Right.Eval(steps)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch n.Op {
+	case "+":
+		result = left + right
+	case "-":
+		result = left - right
+	case "*":
+		result = left * right
+	case "/":
+		if right == 0 {
+			return 0, fmt.Errorf("除零错误: %s / %s", formatNum(left), formatNum(right))
+		}
+		result = left / right
+	case "%":
+		if right == 0 {
+			return 0, fmt.Errorf("取模错误: %s %% %s 中除数为零", formatNum(left), formatNum(right))
+		}
+		result = math.Mod(left, right)
+	case "^":
+		result = math.Pow(left, right)
+	default:
+		return 0, fmt.Errorf("未知运算符 %q", n.Op)
+	}
+
+	if math.IsInf(result, 0) {
+		return 0, fmt.Errorf("结果溢出: %s %s %s", formatNum(left), n.Op, formatNum(right))
+	}
+	if math.IsNaN(result) {
+		return 0, fmt.Errorf("结果不是有效数字: %s %s %s", formatNum(left), n.Op, formatNum(right))
+	}
+
+	*steps = append(*steps, fmt.Sprintf("%s %s %s = %s", formatNum(left), n.Op, formatNum(right), formatNum(result)))
+	return result, nil
+}
+
+// Eval 对函数调用求值，目前支持 sqrt、sin、cos、log
+func (n *CallNode) Eval(steps *[]string) (float64, error) {
+	arg, err := n.Arg.Eval(steps)
+	if err != nil {
+		return 0, err
+	}
+
+	var result float64
+	switch n.Func {
+	case "sqrt":
+		if arg < 0 {
+			return 0, fmt.Errorf("sqrt 的参数不能为负数: %s", formatNum(arg))
+		}
+		result = math.Sqrt(arg)
+	case "sin":
+		result = math.Sin(arg)
+	case "cos":
+		result = math.Cos(arg)
+	case "log":
+		if arg <= 0 {
+			return 0, fmt.Errorf("log 的参数必须为正数: %s", formatNum(arg))
+		}
+		result = math.Log(arg)
+	default:
+		return 0, fmt.Errorf("未知函数 %q", n.Func)
+	}
+
+	*steps = append(*steps, fmt.Sprintf("%s(%s) = %s", n.Func, formatNum(arg), formatNum(result)))
+	return result, nil
+}
+
+// Evaluate 解析并求值一个表达式，返回最终结果和逐步化简的过程
+func Evaluate(expr string) (result float64, steps []string, err error) {
+	node, err := Parse(expr)
+	if err != nil {
+		return 0, nil, err
+	}
+	result, err = node.Eval(&steps)
+	if err != nil {
+		return 0, nil, err
+	}
+	return result, steps, nil
+}
+
+func formatNum(v float64) string {
+	return fmt.Sprintf("%g", v)
+}
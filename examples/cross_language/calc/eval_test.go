@@ -0,0 +1,71 @@
+package calc
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want float64
+	}{
+		{"add", "1 + 2", 3},
+		{"precedence", "2 + 3 * 4", 14},
+		{"parens override precedence", "(2 + 3) * 4", 20},
+		{"caret is right associative", "2 ^ 3 ^ 2", 512},
+		{"unary minus", "-3 + 5", 2},
+		{"unary minus on parens", "-(1 + 2)", -3},
+		{"mod", "7 % 3", 1},
+		{"function call", "sqrt(9)", 3},
+		{"nested function call", "sqrt(sqrt(16))", 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _, err := Evaluate(tc.expr)
+			if err != nil {
+				t.Fatalf("Evaluate(%q) returned error: %v", tc.expr, err)
+			}
+			if math.Abs(got-tc.want) > 1e-9 {
+				t.Errorf("Evaluate(%q) = %v, want %v", tc.expr, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"division by zero", "1 / 0"},
+		{"mod by zero", "1 % 0"},
+		{"overflow", "10 ^ 400"},
+		{"malformed number rejected by parser", "1.2.3 + 1"},
+		{"sqrt of negative", "sqrt(-1)"},
+		{"log of zero", "log(0)"},
+		{"unknown function", "foo(1)"},
+		{"missing closing paren", "(1 + 2"},
+		{"trailing garbage", "1 + 2)"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, _, err := Evaluate(tc.expr); err == nil {
+				t.Errorf("Evaluate(%q) expected an error, got none", tc.expr)
+			}
+		})
+	}
+}
+
+func TestEvaluateSteps(t *testing.T) {
+	_, steps, err := Evaluate("1 + 2 * 3")
+	if err != nil {
+		t.Fatalf("Evaluate returned error: %v", err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 recorded steps, got %d: %v", len(steps), steps)
+	}
+}
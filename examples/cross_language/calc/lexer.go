@@ -0,0 +1,91 @@
+// Package calc 实现一个小型算术表达式求值器：词法分析、语法分析与求值。
+// 拆分成独立的子包是为了让未来的工具（单位换算、符号求导等）可以复用同一套
+// tokenizer/parser，而不必从 go_agent 的 handler 代码里复制。
+package calc
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TokenKind 标识一个 token 的类别
+type TokenKind int
+
+const (
+	TokenNumber TokenKind = iota
+	TokenIdent            // 函数名，如 sqrt、sin、log
+	TokenPlus
+	TokenMinus
+	TokenStar
+	TokenSlash
+	TokenPercent
+	TokenCaret
+	TokenLParen
+	TokenRParen
+	TokenEOF
+)
+
+// Token 是词法分析产出的一个单元
+type Token struct {
+	Kind  TokenKind
+	Value string
+}
+
+// Lex 把表达式字符串切分成 token 序列，遇到无法识别的字符返回 error
+func Lex(expr string) ([]Token, error) {
+	var tokens []Token
+	runes := []rune(expr)
+	i := 0
+
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, Token{Kind: TokenNumber, Value: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, Token{Kind: TokenIdent, Value: strings.ToLower(string(runes[i:j]))})
+			i = j
+		case r == '+':
+			tokens = append(tokens, Token{Kind: TokenPlus, Value: "+"})
+			i++
+		case r == '-':
+			tokens = append(tokens, Token{Kind: TokenMinus, Value: "-"})
+			i++
+		case r == '*':
+			tokens = append(tokens, Token{Kind: TokenStar, Value: "*"})
+			i++
+		case r == '/':
+			tokens = append(tokens, Token{Kind: TokenSlash, Value: "/"})
+			i++
+		case r == '%':
+			tokens = append(tokens, Token{Kind: TokenPercent, Value: "%"})
+			i++
+		case r == '^':
+			tokens = append(tokens, Token{Kind: TokenCaret, Value: "^"})
+			i++
+		case r == '(':
+			tokens = append(tokens, Token{Kind: TokenLParen, Value: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, Token{Kind: TokenRParen, Value: ")"})
+			i++
+		default:
+			return nil, fmt.Errorf("表达式中存在无法识别的字符 %q（位置 %d）", r, i)
+		}
+	}
+
+	tokens = append(tokens, Token{Kind: TokenEOF})
+	return tokens, nil
+}
@@ -0,0 +1,167 @@
+package calc
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Node 是表达式 AST 的一个节点
+type Node interface {
+	Eval(steps *[]string) (float64, error)
+}
+
+// NumberNode 是一个字面量数字
+type NumberNode struct {
+	Value float64
+}
+
+// UnaryNode 是一个一元运算，目前只有取负
+type UnaryNode struct {
+	Op      string
+	Operand Node
+}
+
+// BinaryNode 是一个二元运算
+type BinaryNode struct {
+	Op          string
+	Left, Right Node
+}
+
+// CallNode 是一次函数调用，如 sqrt(x)
+type CallNode struct {
+	Func string
+	Arg  Node
+}
+
+// 运算符的结合优先级，数字越大优先级越高
+var precedence = map[TokenKind]int{
+	TokenPlus:    1,
+	TokenMinus:   1,
+	TokenStar:    2,
+	TokenSlash:   2,
+	TokenPercent: 2,
+	TokenCaret:   3,
+}
+
+// parser 是一个简单的 Pratt 解析器
+type parser struct {
+	tokens []Token
+	pos    int
+}
+
+// Parse 对表达式做词法分析并解析成 AST
+func Parse(expr string) (Node, error) {
+	tokens, err := Lex(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	node, err := p.parseExpr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().Kind != TokenEOF {
+		return nil, fmt.Errorf("表达式在 %q 处存在多余内容", p.peek().Value)
+	}
+	return node, nil
+}
+
+func (p *parser) peek() Token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() Token {
+	t := p.tokens[p.pos]
+	if p.pos < len(p.tokens)-1 {
+		p.pos++
+	}
+	return t
+}
+
+// parseExpr 用优先级爬升（Pratt）算法解析二元表达式
+func (p *parser) parseExpr(minPrec int) (Node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+		prec, ok := precedence[tok.Kind]
+		if !ok || prec < minPrec {
+			break
+		}
+		p.next()
+
+		// ^ 右结合，其余左结合
+		nextMin := prec + 1
+		if tok.Kind == TokenCaret {
+			nextMin = prec
+		}
+		right, err := p.parseExpr(nextMin)
+		if err != nil {
+			return nil, err
+		}
+		left = &BinaryNode{Op: tok.Value, Left: left, Right: right}
+	}
+
+	return left, nil
+}
+
+// parseUnary 处理一元负号，如 -3 或 -(1+2)
+func (p *parser) parseUnary() (Node, error) {
+	if p.peek().Kind == TokenMinus {
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryNode{Op: "-", Operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary 处理数字字面量、函数调用和括号表达式
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.Kind {
+	case TokenNumber:
+		// strconv.ParseFloat 会对整个 token 做严格校验，拒绝像 "1.2.3" 这种
+		// 词法分析阶段允许出现（任意一串数字和点）但不是合法数字的输入；
+		// fmt.Sscanf 只检查前缀，会把 "1.2.3" 悄悄解析成 1.2 并丢弃剩余部分。
+		value, err := strconv.ParseFloat(tok.Value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字 %q: %w", tok.Value, err)
+		}
+		return &NumberNode{Value: value}, nil
+
+	case TokenIdent:
+		if p.peek().Kind != TokenLParen {
+			return nil, fmt.Errorf("函数 %q 后缺少括号", tok.Value)
+		}
+		p.next()
+		arg, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().Kind != TokenRParen {
+			return nil, fmt.Errorf("函数 %q 调用缺少右括号", tok.Value)
+		}
+		p.next()
+		return &CallNode{Func: tok.Value, Arg: arg}, nil
+
+	case TokenLParen:
+		inner, err := p.parseExpr(0)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().Kind != TokenRParen {
+			return nil, fmt.Errorf("缺少右括号")
+		}
+		p.next()
+		return inner, nil
+
+	default:
+		return nil, fmt.Errorf("表达式中存在意外的 token %q", tok.Value)
+	}
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// version 是 go_agent 的发布版本号，随每次打标签手动更新
+const version = "0.1.0"
+
+// globalFlags 是在每个子命令下都可用的公共选项
+type globalFlags struct {
+	format string // json | text | yaml
+	debug  bool
+	config string
+}
+
+// usage 打印顶层用法说明
+func usage() {
+	fmt.Fprintf(os.Stderr, `用法: go_agent <subcommand> [flags]
+
+子命令:
+  run "<prompt>"        处理一条提示并打印结果
+  serve                 从 stdin 读取 JSON 请求 (JSON-RPC 风格)
+  serve --http :8080    以 HTTP 模式监听，暴露 REST + SSE 接口
+  lex "<text>"          对文本做词法切分并打印 token 列表
+  tools list       列出已注册的工具
+  version          打印版本号
+
+全局选项 (置于子命令名之后、位置参数之前，如 go_agent run --format=text "<prompt>"):
+  --format=json|text|yaml   输出格式 (默认 json)
+  --debug                   打印调试日志
+  --config=path             配置文件路径
+`)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case "run":
+		runCmd(os.Args[2:])
+	case "serve":
+		serveCmd(os.Args[2:])
+	case "lex":
+		lexCmd(os.Args[2:])
+	case "tools":
+		toolsCmd(os.Args[2:])
+	case "version":
+		versionCmd(os.Args[2:])
+	case "-h", "--help", "help":
+		usage()
+	default:
+		// 兼容旧的positional调用方式: go_agent "<prompt>"
+		runCmd(os.Args[1:])
+	}
+}
+
+// parseGlobalFlags 为一个子命令的 FlagSet 注册全局选项、解析 args，
+// 并按 flags > env > file > defaults 的优先级叠加出最终生效的 Config。
+func parseGlobalFlags(fs *flag.FlagSet, args []string) *globalFlags {
+	g := &globalFlags{format: "json"}
+	fs.StringVar(&g.format, "format", "json", "输出格式: json|text|yaml")
+	fs.BoolVar(&g.debug, "debug", false, "打印调试日志")
+	fs.StringVar(&g.config, "config", "", "配置文件路径")
+	fs.Parse(args)
+
+	cfg, err := LoadEffectiveConfig(g.config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	formatSet := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "format" {
+			formatSet = true
+		}
+	})
+	if formatSet {
+		cfg.OutputFormat = g.format
+	} else {
+		g.format = cfg.OutputFormat
+	}
+
+	SetActiveConfig(cfg)
+	return g
+}
+
+// runCmd 实现 `go_agent run "<prompt>"`
+func runCmd(args []string) {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	g := parseGlobalFlags(fs, args)
+
+	rest := fs.Args()
+	if len(rest) < 1 {
+		fmt.Fprintln(os.Stderr, "用法: go_agent run [--format=json|text|yaml] \"<prompt>\"")
+		os.Exit(1)
+	}
+	prompt := rest[0]
+
+	if g.debug {
+		fmt.Fprintf(os.Stderr, "[debug] run prompt=%q format=%s config=%s\n", prompt, g.format, g.config)
+	}
+
+	output, err := processPrompt(context.Background(), prompt, nil)
+	status := "success"
+	errMsg := ""
+	if err != nil {
+		status = "error"
+		errMsg = err.Error()
+	}
+	resp := Response{Output: output, Status: status, Error: errMsg}
+
+	printFormatted(g.format, resp)
+}
+
+// serveCmd 实现 `go_agent serve` / `go_agent serve --http :8080`
+func serveCmd(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	httpAddr := fs.String("http", "", "以 HTTP 模式监听该地址，而不是从 stdin 读取 (如 :8080)")
+	g := parseGlobalFlags(fs, args)
+
+	if *httpAddr != "" {
+		if g.debug {
+			fmt.Fprintf(os.Stderr, "[debug] serve --http %s\n", *httpAddr)
+		}
+		if err := RunHTTPServer(*httpAddr); err != nil {
+			fmt.Fprintf(os.Stderr, "http server 退出: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if g.debug {
+		fmt.Fprintln(os.Stderr, "[debug] serve: 从 stdin 读取 JSON-RPC 请求")
+	}
+	serve()
+}
+
+// lexCmd 实现 `go_agent lex "<text>"`，对文本做简单的词法切分
+func lexCmd(args []string) {
+	fs := flag.NewFlagSet("lex", flag.ExitOnError)
+	g := parseGlobalFlags(fs, args)
+
+	rest := fs.Args()
+	if len(rest) < 1 {
+		fmt.Fprintln(os.Stderr, "用法: go_agent lex [--format=json|text|yaml] \"<text>\"")
+		os.Exit(1)
+	}
+
+	tokens := Lex(rest[0])
+	printFormatted(g.format, tokens)
+}
+
+// toolsCmd 实现 `go_agent tools list`
+func toolsCmd(args []string) {
+	fs := flag.NewFlagSet("tools", flag.ExitOnError)
+	g := parseGlobalFlags(fs, args)
+
+	rest := fs.Args()
+	if len(rest) < 1 || rest[0] != "list" {
+		fmt.Fprintln(os.Stderr, "用法: go_agent tools [--format=json|text|yaml] list")
+		os.Exit(1)
+	}
+
+	printFormatted(g.format, ListTools())
+}
+
+// versionCmd 实现 `go_agent version`
+func versionCmd(args []string) {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	g := parseGlobalFlags(fs, args)
+	printFormatted(g.format, map[string]string{"version": version})
+}
+
+// printFormatted 按给定格式把 v 打印到 stdout
+func printFormatted(format string, v interface{}) {
+	switch format {
+	case "text":
+		fmt.Println(formatText(v))
+	case "yaml":
+		data, err := formatYAML(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "格式化失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(data)
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "JSON 序列化失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	}
+}
+
+// formatText 把常见的输出类型渲染成适合终端阅读的纯文本
+func formatText(v interface{}) string {
+	switch val := v.(type) {
+	case Response:
+		if val.Error != "" {
+			return fmt.Sprintf("[%s] %s", val.Status, val.Error)
+		}
+		return val.Output
+	case map[string]string:
+		out := ""
+		for k, vv := range val {
+			out += fmt.Sprintf("%s: %s\n", k, vv)
+		}
+		return out
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+// formatYAML 为简单的 JSON 兼容值生成最小化的 YAML 输出，避免引入外部依赖
+func formatYAML(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return "", err
+	}
+	return yamlEncode(generic, 0), nil
+}
+
+func yamlEncode(v interface{}, indent int) string {
+	pad := ""
+	for i := 0; i < indent; i++ {
+		pad += "  "
+	}
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := ""
+		for k, vv := range val {
+			switch vv.(type) {
+			case map[string]interface{}, []interface{}:
+				out += fmt.Sprintf("%s%s:\n%s", pad, k, yamlEncode(vv, indent+1))
+			default:
+				out += fmt.Sprintf("%s%s: %v\n", pad, k, vv)
+			}
+		}
+		return out
+	case []interface{}:
+		out := ""
+		for _, item := range val {
+			out += fmt.Sprintf("%s- %v\n", pad, item)
+		}
+		return out
+	default:
+		return fmt.Sprintf("%s%v\n", pad, val)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config 保存 agent 的运行期行为：默认 handler、启用的工具、每个工具的子配置、
+// 输出格式和日志级别。可从 agent.json/agent.yaml/agent.toml 加载，
+// 并通过 AGENT_* 环境变量覆盖；最终优先级为 flags > env > file > defaults。
+type Config struct {
+	DefaultHandler        string                       `json:"defaultHandler"`
+	EnabledTools          []string                     `json:"enabledTools"`
+	OutputFormat          string                       `json:"outputFormat"`
+	LogLevel              string                       `json:"logLevel"`
+	RequestTimeoutSeconds int                          `json:"requestTimeoutSeconds"`
+	Tools                 map[string]map[string]string `json:"tools"`
+}
+
+// defaultConfigNames 是在未显式传入 --config 时按顺序尝试加载的文件名
+var defaultConfigNames = []string{"agent.json", "agent.yaml", "agent.yml", "agent.toml"}
+
+// DefaultConfig 返回内置默认值
+func DefaultConfig() *Config {
+	return &Config{
+		OutputFormat:          "json",
+		LogLevel:              "info",
+		RequestTimeoutSeconds: 30,
+		Tools:                 map[string]map[string]string{},
+	}
+}
+
+// LoadEffectiveConfig 按 file < env 的顺序叠加配置，file 为空路径时按
+// defaultConfigNames 自动探测；调用方负责再叠加命令行 flag（最高优先级）。
+func LoadEffectiveConfig(path string) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if path == "" {
+		for _, name := range defaultConfigNames {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+	}
+
+	if path != "" {
+		if err := loadConfigFile(cfg, path); err != nil {
+			return nil, fmt.Errorf("加载配置文件 %s 失败: %w", path, err)
+		}
+	}
+
+	applyEnvOverrides(cfg)
+	return cfg, nil
+}
+
+// loadConfigFile 根据扩展名解析配置文件并合并到 cfg 中
+func loadConfigFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return json.Unmarshal(data, cfg)
+	case ".yaml", ".yml", ".toml":
+		return mergeFlatConfig(cfg, string(data))
+	default:
+		return fmt.Errorf("不支持的配置文件格式: %s", path)
+	}
+}
+
+// mergeFlatConfig 解析一个简单的 `key = value` / `key: value` 风格文件
+// （足以表达 YAML/TOML 的扁平配置，不依赖第三方库）。除了点号路径写法
+// `tools.<name>.<key> = value`，也支持 TOML 的 `[tools.<name>]` table 头——
+// 这是写每工具子配置更符合习惯的方式——header 之后的 key = value 行都归入
+// 该 table，直到遇到下一个 `[...]` 或文件结束。不认识的 section（非
+// `tools.<name>`）里的键会被忽略，而不是被错误地当成顶层键写入 cfg。
+func mergeFlatConfig(cfg *Config, content string) error {
+	var section string // 当前 [section] 头对应的工具名；空字符串表示顶层
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			header := strings.TrimSpace(line[1 : len(line)-1])
+			section = strings.TrimPrefix(header, "tools.")
+			if section == header {
+				// 不是 tools.<name> 形式的 section，之后的键一律忽略
+				section = ""
+				if header != "" {
+					section = unknownSectionMarker
+				}
+			}
+			continue
+		}
+
+		sep := "="
+		if idx := strings.Index(line, ":"); idx >= 0 && (!strings.Contains(line, "=") || idx < strings.Index(line, "=")) {
+			sep = ":"
+		}
+		parts := strings.SplitN(line, sep, 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+
+		if section == unknownSectionMarker {
+			continue
+		}
+		if section != "" {
+			setToolKey(cfg, section, key, value)
+			continue
+		}
+
+		switch {
+		case key == "defaultHandler":
+			cfg.DefaultHandler = value
+		case key == "outputFormat":
+			cfg.OutputFormat = value
+		case key == "logLevel":
+			cfg.LogLevel = value
+		case key == "enabledTools":
+			cfg.EnabledTools = splitCSV(value)
+		case key == "requestTimeoutSeconds":
+			if n, err := strconv.Atoi(value); err == nil {
+				cfg.RequestTimeoutSeconds = n
+			}
+		case strings.HasPrefix(key, "tools."):
+			setToolSetting(cfg, key, value)
+		}
+	}
+	return nil
+}
+
+// unknownSectionMarker 标记一个当前无法识别的 [section]，其内容应被忽略
+const unknownSectionMarker = "\x00unknown"
+
+// setToolKey 把 `[tools.<name>]` table 下的一个 key = value 写入 cfg.Tools[name][key]
+func setToolKey(cfg *Config, name, key, value string) {
+	if cfg.Tools == nil {
+		cfg.Tools = map[string]map[string]string{}
+	}
+	if cfg.Tools[name] == nil {
+		cfg.Tools[name] = map[string]string{}
+	}
+	cfg.Tools[name][key] = value
+}
+
+// setToolSetting 把 `tools.<name>.<key>` 写入 cfg.Tools[name][key]
+func setToolSetting(cfg *Config, dottedKey, value string) {
+	segments := strings.SplitN(strings.TrimPrefix(dottedKey, "tools."), ".", 2)
+	if len(segments) != 2 {
+		return
+	}
+	name, key := segments[0], segments[1]
+	if cfg.Tools == nil {
+		cfg.Tools = map[string]map[string]string{}
+	}
+	if cfg.Tools[name] == nil {
+		cfg.Tools[name] = map[string]string{}
+	}
+	cfg.Tools[name][key] = value
+}
+
+// applyEnvOverrides 用 AGENT_* 环境变量覆盖已加载的配置
+func applyEnvOverrides(cfg *Config) {
+	if v := os.Getenv("AGENT_DEFAULT_HANDLER"); v != "" {
+		cfg.DefaultHandler = v
+	}
+	if v := os.Getenv("AGENT_OUTPUT_FORMAT"); v != "" {
+		cfg.OutputFormat = v
+	}
+	if v := os.Getenv("AGENT_LOG_LEVEL"); v != "" {
+		cfg.LogLevel = v
+	}
+	if v := os.Getenv("AGENT_ENABLED_TOOLS"); v != "" {
+		cfg.EnabledTools = splitCSV(v)
+	}
+	if v := os.Getenv("AGENT_REQUEST_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.RequestTimeoutSeconds = n
+		}
+	}
+}
+
+func splitCSV(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// effectiveRequestTimeout 返回当前生效 Config 里配置的单条请求超时时间，
+// 供 serve/http 两种模式共用；未配置或不大于 0 时取 30 秒
+func effectiveRequestTimeout() time.Duration {
+	secs := activeConfig.RequestTimeoutSeconds
+	if secs <= 0 {
+		secs = 30
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// toolConfigKey 是存放每工具子配置 map 的 context 键类型
+type toolConfigKey struct{}
+
+// withToolConfig 把给定工具的子配置注入到 ctx 中，供 handler 通过 ToolConfigFromContext 读取
+func withToolConfig(ctx context.Context, settings map[string]string) context.Context {
+	return context.WithValue(ctx, toolConfigKey{}, settings)
+}
+
+// ToolConfigFromContext 返回当前 handler 调用对应工具的子配置；不存在时返回 nil
+func ToolConfigFromContext(ctx context.Context) map[string]string {
+	settings, _ := ctx.Value(toolConfigKey{}).(map[string]string)
+	return settings
+}
+
+// toolConfigInt 从子配置中读取一个整数值，缺失或无法解析时返回 fallback
+func toolConfigInt(settings map[string]string, key string, fallback int) int {
+	raw, ok := settings[key]
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileEntry 描述文件遍历结果中的一个条目
+type FileEntry struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"modtime"`
+	IsDir   bool   `json:"isDir"`
+}
+
+// listFilesParams 是 handleListFiles 接受的 JSON 参数
+type listFilesParams struct {
+	Root           string   `json:"root"`
+	Include        []string `json:"include"`
+	Exclude        []string `json:"exclude"`
+	MaxDepth       int      `json:"maxDepth"`
+	FollowSymlinks bool     `json:"followSymlinks"`
+}
+
+// defaultListFilesParams 是未提供 params 时使用的默认值
+var defaultListFilesParams = listFilesParams{
+	Root:     ".",
+	MaxDepth: -1,
+}
+
+// handleListFiles 处理文件列表请求：遍历目录树，汇总成一个 JSON 数组返回。
+// WalkFiles 本身按 emit 回调逐项产出，遍历阶段不会把整棵树先放进内存；但
+// Handler 接口要求返回一个完整的 string，所以这里最终仍会把全部条目攒进一次
+// JSON 编码里——总输出大小依旧是 O(树大小)，并不能把内存占用限制住。
+// 真正需要内存占用与树大小无关的调用方（如 handlePromptStream）应改用下面的
+// streamListFiles，它复用同一个 WalkFiles，但把每个条目单独 emit 出去，不在
+// 内存里拼接。
+func handleListFiles(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
+	p, err := resolveListFilesParams(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('[')
+	first := true
+
+	err = WalkFiles(ctx, p, func(entry FileEntry) error {
+		if !first {
+			sb.WriteByte(',')
+		}
+		first = false
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		sb.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sb.WriteByte(']')
+	return sb.String(), nil
+}
+
+// streamListFiles 和 handleListFiles 解析同样的参数、复用同一个 WalkFiles，
+// 但不在内存里拼接结果：每发现一个条目就序列化成一行 JSON 并调用一次 emit。
+// 调用方（如 handlePromptStream）可以直接把每次 emit 转发给客户端，内存占用
+// 因此只和单个 FileEntry 相关，与目录树大小无关——这是对 handleListFiles 文档
+// 中所说内存占用限制不住问题的真正解决方案，而不是换一种说法重新描述它。
+func streamListFiles(ctx context.Context, prompt string, params json.RawMessage, emit func(chunk string) error) error {
+	p, err := resolveListFilesParams(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return WalkFiles(ctx, p, func(entry FileEntry) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return emit(string(data))
+	})
+}
+
+// resolveListFilesParams 合并默认值、tools.files 子配置和请求自带的 JSON params，
+// 供 handleListFiles 和 streamListFiles 共用
+func resolveListFilesParams(ctx context.Context, params json.RawMessage) (listFilesParams, error) {
+	p := defaultListFilesParams
+
+	// tools.files.maxDepth 来自 agent.{json,yaml,toml} 配置文件（AGENT_* 环境变量
+	// 不覆盖每工具子配置），优先级低于请求自带的 JSON params（见下方的 json.Unmarshal）。
+	p.MaxDepth = toolConfigInt(ToolConfigFromContext(ctx), "maxDepth", p.MaxDepth)
+
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return p, fmt.Errorf("解析 list-files 参数失败: %w", err)
+		}
+		if p.Root == "" {
+			p.Root = "."
+		}
+		if p.MaxDepth == 0 {
+			p.MaxDepth = -1
+		}
+	}
+
+	return p, nil
+}
+
+// WalkFiles 递归遍历 root 下的文件树，对每个通过 include/exclude 过滤的条目调用 emit。
+// emit 按发现顺序逐项调用，调用方若直接把条目写到输出流（而不是像
+// handleListFiles 那样先攒成一个字符串）就能真正做到内存占用与树大小无关。
+func WalkFiles(ctx context.Context, p listFilesParams, emit func(FileEntry) error) error {
+	root := p.Root
+	rootDepth := strings.Count(filepath.Clean(root), string(os.PathSeparator))
+
+	walkFn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if path != root {
+			depth := strings.Count(filepath.Clean(path), string(os.PathSeparator)) - rootDepth
+			if p.MaxDepth >= 0 && depth > p.MaxDepth {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// exclude 命中一个目录时要整体剪枝，否则 Walk 仍会下探到其子项，
+			// 只是不 emit 目录本身——子文件依旧会被遍历到并可能重新匹配上。
+			if isExcluded(path, p.Exclude) {
+				if info.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			// include 不匹配只代表目录本身不出现在结果里，子项可能仍然匹配，
+			// 这里不能 SkipDir，必须继续下探子目录。
+			if !matchesInclude(path, p.Include) {
+				return nil
+			}
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 && !p.FollowSymlinks {
+			return nil
+		}
+
+		if path == root && info.IsDir() {
+			return nil
+		}
+
+		return emit(FileEntry{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime().UTC().Format("2006-01-02T15:04:05Z"),
+			IsDir:   info.IsDir(),
+		})
+	}
+
+	return filepath.Walk(root, walkFn)
+}
+
+// isExcluded 判断 path 的 base 名是否命中任一 exclude glob 模式
+func isExcluded(path string, exclude []string) bool {
+	base := filepath.Base(path)
+	for _, pattern := range exclude {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesInclude 判断 path 的 base 名是否命中某个 include glob 模式；
+// include 为空表示全部匹配
+func matchesInclude(path string, include []string) bool {
+	if len(include) == 0 {
+		return true
+	}
+	base := filepath.Base(path)
+	for _, pattern := range include {
+		if ok, _ := filepath.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -4,10 +4,15 @@
 // 用于演示 TigerHill 如何测试 Go 语言的 Agent
 //
 // 编译:
-//   go build -o go_agent go_agent.go
+//   go build -o go_agent *.go
 //
 // 使用:
-//   ./go_agent "你的提示"
+//   ./go_agent run "你的提示"
+//   ./go_agent serve             # JSON-RPC over stdin/stdout
+//   ./go_agent serve --http :8080  # REST + SSE over HTTP
+//   ./go_agent tools list
+//   ./go_agent lex "你的文本"
+//   ./go_agent version
 //
 // 测试:
 //   python examples/cross_language/test_go_agent.py
@@ -15,82 +20,112 @@
 package main
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+
+	"github.com/Click-Intelligence-LLC/TigerHill/examples/cross_language/calc"
 )
 
 // Response 表示 Agent 的响应
 type Response struct {
+	ID     string `json:"id,omitempty"`
 	Output string `json:"output"`
 	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
 }
 
-func main() {
-	// 检查参数
-	if len(os.Args) < 2 {
-		fmt.Fprintf(os.Stderr, "用法: %s <prompt>\n", os.Args[0])
-		os.Exit(1)
-	}
-
-	// 获取提示
-	prompt := os.Args[1]
-
-	// 处理提示
-	output := processPrompt(prompt)
-
-	// 输出 JSON 响应
-	response := Response{
-		Output: output,
-		Status: "success",
-	}
+// Request 表示通过 serve 模式在 stdin 上接收到的一条 JSON-RPC 风格请求
+type Request struct {
+	ID     string          `json:"id"`
+	Prompt string          `json:"prompt"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
 
-	jsonOutput, err := json.Marshal(response)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "JSON 序列化失败: %v\n", err)
-		os.Exit(1)
+// serve 在 serve 模式下运行：从 stdin 逐行读取 JSON 请求，
+// 处理后将 JSON 响应写到 stdout，直到 stdin 关闭或收到 SIGINT/SIGTERM。
+func serve() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	in := bufio.NewScanner(os.Stdin)
+	in.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for in.Scan() {
+			lines <- in.Text()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			writeResponse(out, handleServeLine(ctx, line))
+		}
 	}
-
-	fmt.Println(string(jsonOutput))
 }
 
-// processPrompt 处理用户提示
-func processPrompt(prompt string) string {
-	lowerPrompt := strings.ToLower(prompt)
-
-	// 检查关键词并返回相应响应
-	switch {
-	case strings.Contains(lowerPrompt, "文件") || strings.Contains(lowerPrompt, "list"):
-		return handleListFiles(prompt)
-
-	case strings.Contains(lowerPrompt, "代码") || strings.Contains(lowerPrompt, "code"):
-		return handleCodeGeneration(prompt)
-
-	case strings.Contains(lowerPrompt, "分析") || strings.Contains(lowerPrompt, "analyze"):
-		return handleAnalysis(prompt)
+// handleServeLine 解析并处理一条 stdin 输入，返回要写回 stdout 的响应
+func handleServeLine(ctx context.Context, line string) Response {
+	var req Request
+	if err := json.Unmarshal([]byte(line), &req); err != nil {
+		return Response{Status: "error", Error: fmt.Sprintf("无效的 JSON 请求: %v", err)}
+	}
 
-	case strings.Contains(lowerPrompt, "计算") || strings.Contains(lowerPrompt, "calculate"):
-		return handleCalculation(prompt)
+	reqCtx, cancel := context.WithTimeout(ctx, effectiveRequestTimeout())
+	defer cancel()
 
-	default:
-		return fmt.Sprintf("Go Agent 处理: %s", prompt)
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := processPrompt(reqCtx, req.Prompt, req.Params)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil {
+			return Response{ID: req.ID, Status: "error", Error: r.err.Error()}
+		}
+		return Response{ID: req.ID, Output: r.output, Status: "success"}
+	case <-reqCtx.Done():
+		return Response{ID: req.ID, Status: "error", Error: "请求超时"}
 	}
 }
 
-// handleListFiles 处理文件列表请求
-func handleListFiles(prompt string) string {
-	return `Go Agent 文件列表功能：
-- main.go
-- utils.go
-- config.yaml
-- README.md
-
-这是一个模拟的文件列表。`
+// writeResponse 将响应编码为单行 JSON 并写出，保证每个响应独占一行
+func writeResponse(out *bufio.Writer, resp Response) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		data, _ = json.Marshal(Response{ID: resp.ID, Status: "error", Error: fmt.Sprintf("JSON 序列化失败: %v", err)})
+	}
+	out.Write(data)
+	out.WriteByte('\n')
+	out.Flush()
 }
 
 // handleCodeGeneration 处理代码生成请求
-func handleCodeGeneration(prompt string) string {
+func handleCodeGeneration(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
 	if strings.Contains(prompt, "Go") || strings.Contains(prompt, "go") {
 		return `这是一个 Go 函数示例:
 
@@ -105,14 +140,14 @@ func add(a, b int) int {
 func main() {
     result := add(5, 3)
     fmt.Println(result)  // 输出: 8
-}`
+}`, nil
 	}
 
-	return "我可以帮您生成 Go 代码。请在提示中包含 'Go'。"
+	return "我可以帮您生成 Go 代码。请在提示中包含 'Go'。", nil
 }
 
 // handleAnalysis 处理分析请求
-func handleAnalysis(prompt string) string {
+func handleAnalysis(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
 	return `Go Agent 分析结果：
 
 1. 代码质量: 优秀
@@ -122,10 +157,63 @@ func handleAnalysis(prompt string) string {
    - 增加单元测试
    - 优化算法复杂度
 
-这是一个 Go Agent 提供的分析报告。`
+这是一个 Go Agent 提供的分析报告。`, nil
+}
+
+// calcResult 是 handleCalculation 的 JSON 输出结构
+type calcResult struct {
+	Expression string   `json:"expression"`
+	Result     float64  `json:"result"`
+	Steps      []string `json:"steps"`
+}
+
+// handleCalculation 处理计算请求：从提示中提取表达式，用 calc 包词法分析、
+// 解析并求值
+func handleCalculation(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
+	expr := extractExpression(prompt)
+	if expr == "" {
+		return "", fmt.Errorf("未能在提示中找到可计算的表达式")
+	}
+
+	result, steps, err := calc.Evaluate(expr)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(calcResult{Expression: expr, Result: result, Steps: steps})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// extractExpression 去掉提示中的触发关键词，剩下的部分视为待求值表达式。
+// registry.go 里的关键词匹配是基于小写后的 prompt 做的（"Calculate 3+4" 能匹配
+// 到 "calculate"），所以这里也必须不区分大小写地剥离，否则 "Calculate" 这种
+// 大小写变体会被判定为命中了 calculate 工具，却剥离不掉触发词本身。
+func extractExpression(prompt string) string {
+	expr := prompt
+	for _, kw := range []string{"计算", "calculate"} {
+		expr = removeCaseInsensitive(expr, kw)
+	}
+	return strings.TrimSpace(expr)
 }
 
-// handleCalculation 处理计算请求
-func handleCalculation(prompt string) string {
-	return "Go Agent 计算器功能正在开发中。"
+// removeCaseInsensitive 不区分大小写地删除 s 中所有的 substr 子串
+func removeCaseInsensitive(s, substr string) string {
+	lower := strings.ToLower(s)
+	substr = strings.ToLower(substr)
+
+	var sb strings.Builder
+	i := 0
+	for {
+		idx := strings.Index(lower[i:], substr)
+		if idx < 0 {
+			sb.WriteString(s[i:])
+			break
+		}
+		sb.WriteString(s[i : i+idx])
+		i += idx + len(substr)
+	}
+	return sb.String()
 }
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpMiddleware 包裹一个 http.Handler，用于注入请求 ID、日志和 panic 恢复
+type httpMiddleware func(http.Handler) http.Handler
+
+// RunHTTPServer 启动 HTTP 服务，暴露 /v1/prompt、/v1/prompt/stream、/v1/tools 和 /healthz
+func RunHTTPServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/v1/prompt", handlePromptHTTP)
+	mux.HandleFunc("/v1/prompt/stream", handlePromptStream)
+	mux.HandleFunc("/v1/tools", handleToolsHTTP)
+
+	handler := chainMiddleware(mux, requestIDMiddleware, loggingHTTPMiddleware, recoveryHTTPMiddleware)
+
+	server := &http.Server{
+		Addr:    addr,
+		Handler: handler,
+	}
+	log.Printf("http server listening on %s", addr)
+	return server.ListenAndServe()
+}
+
+func chainMiddleware(h http.Handler, mws ...httpMiddleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// requestIDContextKey 是存放请求 ID 的 context 键类型
+type requestIDContextKey struct{}
+
+// requestIDMiddleware 为每个请求确定一个 ID 并写入响应头和 context：请求头已带
+// X-Request-ID 时沿用该值，否则生成一个随机 ID
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// loggingHTTPMiddleware 记录每个请求的方法、路径、请求 ID 和耗时
+func loggingHTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		id, _ := r.Context().Value(requestIDContextKey{}).(string)
+		log.Printf("request_id=%s method=%s path=%s duration=%s", id, r.Method, r.URL.Path, time.Since(start))
+	})
+}
+
+// recoveryHTTPMiddleware 捕获 handler 内部的 panic，返回 500 而不是让进程崩溃
+func recoveryHTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				log.Printf("panic recovered: %v", rec)
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// handleHealthz 是一个简单的存活探针
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handlePromptHTTP 实现 POST /v1/prompt：解析请求体、运行 processPrompt、返回 Response
+func handlePromptHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, Response{Status: "error", Error: fmt.Sprintf("无效的请求体: %v", err)})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), effectiveRequestTimeout())
+	defer cancel()
+
+	output, err := processPrompt(ctx, req.Prompt, req.Params)
+	if err != nil {
+		writeJSON(w, http.StatusOK, Response{ID: req.ID, Status: "error", Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, Response{ID: req.ID, Output: output, Status: "success"})
+}
+
+// handleToolsHTTP 实现 GET /v1/tools
+func handleToolsHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, ListTools())
+}
+
+// handlePromptStream 实现 POST /v1/prompt/stream。对注册了 StreamHandler 的工具
+// （目前只有 files），调用 LookupStreamTool 拿到的流式实现真正逐条 emit、逐条发送
+// SSE 帧，不会先把整个结果攒在内存里；工具在产出下一条之前，上一条已经到达客户端。
+// 对没有流式实现的工具（code/analyze/calculate——它们的输出是一次性算出来的，没有
+// 自然的分片边界），仍然回退到等 processPrompt 算出完整结果后按行分帧发送；这种
+// 情况下请求依旧会阻塞到算完为止，和非流式接口没有区别，但这是诚实的退化路径，
+// 而不是对所有工具都声称做到了增量生成。
+func handlePromptStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("无效的请求体: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ctx, cancel := context.WithTimeout(r.Context(), effectiveRequestTimeout())
+	defer cancel()
+
+	if _, stream, settings, ok := LookupStreamTool(req.Prompt); ok {
+		streamCtx := withToolConfig(ctx, settings)
+		err := stream(streamCtx, req.Prompt, req.Params, func(chunk string) error {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			flusher.Flush()
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+			flusher.Flush()
+			return
+		}
+		fmt.Fprintf(w, "event: done\ndata: {}\n\n")
+		flusher.Flush()
+		return
+	}
+
+	output, err := processPrompt(ctx, req.Prompt, req.Params)
+	if err != nil {
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", err.Error())
+		flusher.Flush()
+		return
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fmt.Fprintf(w, "data: %s\n\n", scanner.Text())
+		flusher.Flush()
+	}
+	fmt.Fprintf(w, "event: done\ndata: {}\n\n")
+	flusher.Flush()
+}
+
+// writeJSON 把 v 编码为 JSON 并以给定状态码写入响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,47 @@
+package main
+
+import "unicode"
+
+// Token 是 Lex 产出的一个词法单元
+type Token struct {
+	Kind  string `json:"kind"` // word | number | symbol | space
+	Value string `json:"value"`
+}
+
+// Lex 对输入文本做一次粗粒度的词法切分，将其划分为单词、数字、符号和空白，
+// 供 `go_agent lex` 子命令展示。中文按 Unicode 字母类处理，与英文单词同归为 word。
+func Lex(input string) []Token {
+	runes := []rune(input)
+	var tokens []Token
+	i := 0
+	for i < len(runes) {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			j := i
+			for j < len(runes) && unicode.IsSpace(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, Token{Kind: "space", Value: string(runes[i:j])})
+			i = j
+		case unicode.IsDigit(r):
+			j := i
+			for j < len(runes) && (unicode.IsDigit(runes[j]) || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, Token{Kind: "number", Value: string(runes[i:j])})
+			i = j
+		case unicode.IsLetter(r):
+			j := i
+			for j < len(runes) && unicode.IsLetter(runes[j]) {
+				j++
+			}
+			tokens = append(tokens, Token{Kind: "word", Value: string(runes[i:j])})
+			i = j
+		default:
+			tokens = append(tokens, Token{Kind: "symbol", Value: string(r)})
+			i++
+		}
+	}
+	return tokens
+}
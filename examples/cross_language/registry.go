@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Handler 是一个工具处理函数：接收上下文、原始提示和可选参数，返回文本结果。
+// 这个签名天然只能产出一个完整的字符串——handler 内部没有办法在算出最终结果
+// 之前吐出部分输出，所以基于它包装的任何“流式”接口都只是把一次性结果分帧发出，
+// 并不能降低内存占用或是先发后续数据。真正能增量产出的工具需要改用下面的 StreamHandler。
+type Handler func(ctx context.Context, prompt string, params json.RawMessage) (string, error)
+
+// StreamHandler 是一个支持增量产出的工具处理函数：每算出一个逻辑分片（如一个
+// FileEntry）就调用一次 emit，而不是把完整结果拼成一个字符串再返回。emit 的
+// 参数是一个已经序列化好的分片（如一行 JSON），可以直接写给客户端，调用方因此
+// 能做到内存占用只和单个分片相关，与整体结果大小无关。
+// 并不是每个工具都能拆成这种形式——handleCodeGeneration/handleAnalysis 的输出
+// 是一次性算出来的，没有自然的分片边界，因此只有 files 工具注册了 StreamHandler。
+type StreamHandler func(ctx context.Context, prompt string, params json.RawMessage, emit func(chunk string) error) error
+
+// Middleware 包裹一个 Handler，用于在调用前后注入横切逻辑（日志、计时、恢复等）
+type Middleware func(name string, next Handler) Handler
+
+// tool 是注册表中的一项：名称、用于关键词匹配的触发词，以及处理函数；
+// Stream 为空表示该工具不支持增量产出，只能走 Handler 的一次性返回路径
+type tool struct {
+	Name     string        `json:"name"`
+	Keywords []string      `json:"keywords"`
+	Handler  Handler       `json:"-"`
+	Stream   StreamHandler `json:"-"`
+}
+
+// registry 保存按注册顺序排列的全部工具，关键词匹配时按此顺序查找
+var registry []tool
+
+// activeConfig 是当前生效的 Config，由 main/cli 在启动时通过 SetActiveConfig 设置
+var activeConfig = DefaultConfig()
+
+// SetActiveConfig 切换 processPrompt 使用的 Config，handler 将通过
+// ToolConfigFromContext 拿到各自的子配置
+func SetActiveConfig(cfg *Config) {
+	activeConfig = cfg
+}
+
+// middlewares 是包裹每次工具调用的中间件链，按声明顺序从外到内执行
+var middlewares = []Middleware{loggingMiddleware, timingMiddleware, recoveryMiddleware}
+
+// RegisterTool 将一个处理函数注册到指定名称和关键词下，供 processPrompt 分发调用
+func RegisterTool(name string, keywords []string, handler Handler) {
+	registry = append(registry, tool{Name: name, Keywords: keywords, Handler: handler})
+}
+
+// RegisterStreamTool 和 RegisterTool 一样注册一个工具，但额外挂上一个 StreamHandler，
+// 供 handlePromptStream 在能找到流式实现时优先调用，从而真正实现增量输出
+func RegisterStreamTool(name string, keywords []string, handler Handler, stream StreamHandler) {
+	registry = append(registry, tool{Name: name, Keywords: keywords, Handler: handler, Stream: stream})
+}
+
+func init() {
+	RegisterStreamTool("files", []string{"文件", "list"}, handleListFiles, streamListFiles)
+	RegisterTool("code", []string{"代码", "code"}, handleCodeGeneration)
+	RegisterTool("analyze", []string{"分析", "analyze"}, handleAnalysis)
+	RegisterTool("calculate", []string{"计算", "calculate"}, handleCalculation)
+}
+
+// processPrompt 在已启用的工具中查找第一个关键词匹配的工具并经由中间件链调用它；
+// 没有匹配时优先落回 Config.DefaultHandler（如果配置了且已启用），否则返回默认的回显响应
+func processPrompt(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
+	lowerPrompt := strings.ToLower(prompt)
+
+	for _, t := range registry {
+		if !toolEnabled(t.Name) {
+			continue
+		}
+		for _, kw := range t.Keywords {
+			if strings.Contains(lowerPrompt, kw) {
+				return invokeTool(ctx, t, prompt, params)
+			}
+		}
+	}
+
+	if activeConfig.DefaultHandler != "" {
+		for _, t := range registry {
+			if t.Name == activeConfig.DefaultHandler && toolEnabled(t.Name) {
+				return invokeTool(ctx, t, prompt, params)
+			}
+		}
+	}
+
+	return fmt.Sprintf("Go Agent 处理: %s", prompt), nil
+}
+
+// LookupStreamTool 按 processPrompt 同样的关键词匹配规则查找第一个匹配到的已启用工具，
+// 但只返回注册了 StreamHandler 的结果；没有流式实现的工具（如 code/analyze/calculate）
+// 不会被匹配到，调用方应回退到 processPrompt 的一次性结果再分帧发送
+func LookupStreamTool(prompt string) (name string, stream StreamHandler, settings map[string]string, ok bool) {
+	lowerPrompt := strings.ToLower(prompt)
+
+	for _, t := range registry {
+		if t.Stream == nil || !toolEnabled(t.Name) {
+			continue
+		}
+		for _, kw := range t.Keywords {
+			if strings.Contains(lowerPrompt, kw) {
+				return t.Name, t.Stream, activeConfig.Tools[t.Name], true
+			}
+		}
+	}
+
+	return "", nil, nil, false
+}
+
+// invokeTool 注入该工具的子配置并经由中间件链调用它
+func invokeTool(ctx context.Context, t tool, prompt string, params json.RawMessage) (string, error) {
+	ctx = withToolConfig(ctx, activeConfig.Tools[t.Name])
+	return withMiddleware(t.Name, t.Handler)(ctx, prompt, params)
+}
+
+// toolEnabled 判断一个工具是否处于启用状态；Config.EnabledTools 为空表示全部启用
+func toolEnabled(name string) bool {
+	if len(activeConfig.EnabledTools) == 0 {
+		return true
+	}
+	for _, n := range activeConfig.EnabledTools {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// withMiddleware 按声明顺序把中间件链包裹在 handler 外层
+func withMiddleware(name string, h Handler) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](name, h)
+	}
+	return h
+}
+
+// loggingMiddleware 记录每次工具调用及其结果状态
+func loggingMiddleware(name string, next Handler) Handler {
+	return func(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
+		output, err := next(ctx, prompt, params)
+		if err != nil {
+			log.Printf("tool=%s status=error err=%v", name, err)
+		} else {
+			log.Printf("tool=%s status=ok", name)
+		}
+		return output, err
+	}
+}
+
+// timingMiddleware 记录每次工具调用耗时
+func timingMiddleware(name string, next Handler) Handler {
+	return func(ctx context.Context, prompt string, params json.RawMessage) (string, error) {
+		start := time.Now()
+		output, err := next(ctx, prompt, params)
+		log.Printf("tool=%s duration=%s", name, time.Since(start))
+		return output, err
+	}
+}
+
+// recoveryMiddleware 捕获 handler 内部的 panic，将其转换为普通 error
+func recoveryMiddleware(name string, next Handler) (h Handler) {
+	return func(ctx context.Context, prompt string, params json.RawMessage) (output string, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("tool %s panicked: %v", name, r)
+			}
+		}()
+		return next(ctx, prompt, params)
+	}
+}
+
+// ListTools 返回已注册工具的名称和关键词，供 `go_agent tools list` 和 GET /v1/tools 使用
+func ListTools() []tool {
+	return registry
+}